@@ -0,0 +1,15 @@
+package serial
+
+import "errors"
+
+// ErrBadBaud is returned when SetBaud is called with a baud rate that
+// is not a positive integer.
+var ErrBadBaud = errors.New("serial: invalid baud rate")
+
+// ErrNotSupported is returned by methods that have no implementation
+// on the current platform.
+var ErrNotSupported = errors.New("serial: not supported on this platform")
+
+// ErrPortClosed is returned by in-flight operations when the port is
+// closed out from under them.
+var ErrPortClosed = errors.New("serial: port closed")
@@ -0,0 +1,67 @@
+package serial
+
+import (
+	"context"
+	"time"
+)
+
+// ReadContext is like Read, but returns ctx.Err() if ctx is cancelled
+// or its deadline passes before a Read completes, by forcing the
+// pending Read to unblock via SetReadDeadline. The deadline is reset
+// once the Read unblocks, so a cancelled ReadContext doesn't leave the
+// port timing out on every subsequent Read. Where SetReadDeadline
+// returns ErrNotSupported (the darwin and windows Port), the pending
+// Read is not interrupted: ReadContext waits for it to return on its
+// own and then returns ctx.Err().
+func (p *Port) ReadContext(ctx context.Context, b []byte) (int, error) {
+	type result struct {
+		n   int
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		n, err := p.Read(b)
+		done <- result{n, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.n, r.err
+	case <-ctx.Done():
+		p.SetReadDeadline(time.Now())
+		<-done
+		p.resetReadDeadline()
+		return 0, ctx.Err()
+	}
+}
+
+// WriteContext is like Write, but returns ctx.Err() if ctx is
+// cancelled or its deadline passes before a Write completes, by
+// forcing the pending Write to unblock via SetWriteDeadline. The
+// deadline is reset once the Write unblocks, so a cancelled
+// WriteContext doesn't leave the port timing out on every subsequent
+// Write. Where SetWriteDeadline returns ErrNotSupported (the darwin
+// and windows Port), the pending Write is not interrupted:
+// WriteContext waits for it to return on its own and then returns
+// ctx.Err().
+func (p *Port) WriteContext(ctx context.Context, b []byte) (int, error) {
+	type result struct {
+		n   int
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		n, err := p.Write(b)
+		done <- result{n, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.n, r.err
+	case <-ctx.Done():
+		p.SetWriteDeadline(time.Now())
+		<-done
+		p.resetWriteDeadline()
+		return 0, ctx.Err()
+	}
+}
@@ -0,0 +1,59 @@
+package modbus
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+)
+
+// ASCIIHandler implements Handler using Modbus ASCII framing: a
+// leading ':', the hex encoding of [slave | function | data | LRC],
+// and a trailing "\r\n".
+type ASCIIHandler struct{}
+
+// NewASCIIHandler returns an ASCIIHandler.
+func NewASCIIHandler() *ASCIIHandler {
+	return &ASCIIHandler{}
+}
+
+func (h *ASCIIHandler) Terminator() []byte {
+	return []byte("\r\n")
+}
+
+func (h *ASCIIHandler) Encode(slave byte, pdu []byte) []byte {
+	data := make([]byte, 0, 1+len(pdu)+1)
+	data = append(data, slave)
+	data = append(data, pdu...)
+	data = append(data, lrc(data))
+
+	frame := make([]byte, 0, 1+len(data)*2+2)
+	frame = append(frame, ':')
+	frame = append(frame, strings.ToUpper(hex.EncodeToString(data))...)
+	return append(frame, '\r', '\n')
+}
+
+func (h *ASCIIHandler) Decode(frame []byte) (slave byte, pdu []byte, err error) {
+	frame = bytes.TrimSuffix(frame, []byte("\r\n"))
+	if len(frame) < 1 || frame[0] != ':' {
+		return 0, nil, ErrFrame
+	}
+	data, decErr := hex.DecodeString(string(frame[1:]))
+	if decErr != nil || len(data) < 2 {
+		return 0, nil, ErrFrame
+	}
+	body, gotLRC := data[:len(data)-1], data[len(data)-1]
+	if lrc(body) != gotLRC {
+		return 0, nil, ErrFrame
+	}
+	return body[0], body[1:], nil
+}
+
+// lrc computes the Modbus ASCII LRC: the two's complement of the sum
+// of data's bytes.
+func lrc(data []byte) byte {
+	var sum byte
+	for _, b := range data {
+		sum += b
+	}
+	return byte(-int8(sum))
+}
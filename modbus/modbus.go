@@ -0,0 +1,292 @@
+// Package modbus implements Modbus RTU and Modbus ASCII client
+// transports on top of a serial.Transport. The wire framing is
+// pluggable via the Handler interface so callers can pick RTU or
+// ASCII without changing any of the request/response code.
+package modbus
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"shayden/serial"
+)
+
+// Function codes used by the Client's high level methods.
+const (
+	funcReadCoils              = 0x01
+	funcReadDiscreteInputs     = 0x02
+	funcReadHoldingRegisters   = 0x03
+	funcReadInputRegisters     = 0x04
+	funcWriteSingleCoil        = 0x05
+	funcWriteSingleRegister    = 0x06
+	funcWriteMultipleCoils     = 0x0F
+	funcWriteMultipleRegisters = 0x10
+
+	exceptionBit = 0x80
+
+	coilOn  = 0xFF00
+	coilOff = 0x0000
+)
+
+// ErrFrame is returned when a response frame fails checksum validation
+// or cannot otherwise be parsed by the Handler.
+var ErrFrame = errors.New("modbus: invalid frame")
+
+// ErrShortResponse is returned when a response does not carry enough
+// bytes for the data it claims to hold.
+var ErrShortResponse = errors.New("modbus: response too short")
+
+// ErrTimeout is returned when no response is assembled before the
+// port's read timeout elapses.
+var ErrTimeout = errors.New("modbus: timed out waiting for response")
+
+// ExceptionError is returned when a slave replies with a Modbus
+// exception response, i.e. a response whose function code has its
+// high bit set.
+type ExceptionError struct {
+	Function byte
+	Code     byte
+}
+
+func (e *ExceptionError) Error() string {
+	return fmt.Sprintf("modbus: slave returned exception 0x%02x for function 0x%02x", e.Code, e.Function&^exceptionBit)
+}
+
+// Handler frames and unframes PDUs for a particular Modbus variant.
+// RTUHandler and ASCIIHandler are the two implementations provided by
+// this package.
+type Handler interface {
+	// Encode wraps slave and pdu (function code followed by data)
+	// into a complete frame ready to be written to the port.
+	Encode(slave byte, pdu []byte) []byte
+	// Decode validates a complete frame read from the port and
+	// returns the slave address and PDU it carries.
+	Decode(frame []byte) (slave byte, pdu []byte, err error)
+	// Terminator returns the byte sequence that marks the end of a
+	// frame, or nil if the variant has no in-band terminator, in
+	// which case the end of a frame is inferred from the inter-frame
+	// silence (a Read that returns no new bytes).
+	Terminator() []byte
+}
+
+// Client is a Modbus client (master) that issues requests to a single
+// slave device over a serial.Transport, using a Handler to frame and
+// unframe PDUs. Any Transport works, including serial.Pipe for tests
+// and serial.DialRFC2217 for a networked serial server.
+type Client struct {
+	port    serial.Transport
+	handler Handler
+	slave   byte
+	retries int
+}
+
+// NewClient returns a Client that talks to the slave at address slave
+// over port, using handler for framing. Transactions that fail with a
+// framing or checksum error are retried up to 3 times by default; use
+// SetRetries to change that.
+func NewClient(port serial.Transport, handler Handler, slave byte) *Client {
+	return &Client{port: port, handler: handler, slave: slave, retries: 3}
+}
+
+// SetRetries sets how many times a transaction is retried after a
+// framing or checksum error before Send gives up and returns the last
+// error seen.
+func (c *Client) SetRetries(n int) {
+	c.retries = n
+}
+
+// Send writes pdu (function code followed by data, excluding the slave
+// address and checksum, which the Handler adds) to the slave and
+// returns the response PDU, retrying on framing or checksum errors.
+func (c *Client) Send(pdu []byte) ([]byte, error) {
+	var lastErr error
+	for attempt := 0; attempt <= c.retries; attempt++ {
+		if rtu, ok := c.handler.(interface{ InterFrameDelay() time.Duration }); ok {
+			time.Sleep(rtu.InterFrameDelay())
+		}
+		if err := c.port.Flush(); err != nil {
+			return nil, err
+		}
+		if _, err := c.port.Write(c.handler.Encode(c.slave, pdu)); err != nil {
+			return nil, err
+		}
+
+		frame, err := c.readFrame()
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		slave, respPDU, err := c.handler.Decode(frame)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if slave != c.slave {
+			lastErr = ErrFrame
+			continue
+		}
+		if len(respPDU) > 0 && respPDU[0]&exceptionBit != 0 {
+			code := byte(0)
+			if len(respPDU) > 1 {
+				code = respPDU[1]
+			}
+			return nil, &ExceptionError{Function: respPDU[0], Code: code}
+		}
+		return respPDU, nil
+	}
+	return nil, lastErr
+}
+
+// readFrame accumulates bytes from the port until the Handler's
+// terminator is seen, or, for terminator-less variants, until a Read
+// returns no new bytes (the inter-frame silence).
+func (c *Client) readFrame() ([]byte, error) {
+	term := c.handler.Terminator()
+	buf := make([]byte, 0, 256)
+	tmp := make([]byte, 256)
+	for {
+		n, err := c.port.Read(tmp)
+		if n > 0 {
+			buf = append(buf, tmp[:n]...)
+			if term != nil && hasSuffix(buf, term) {
+				return buf, nil
+			}
+		}
+		if err != nil || n == 0 {
+			// For terminator-less framing (RTU), the deadline/EOF
+			// error that unblocks Read here, or a Read that returns
+			// no bytes and no error (a Windows read timeout), is
+			// itself the inter-frame silence that marks the end of a
+			// frame, not a failure, as long as something was actually
+			// received.
+			if term == nil && len(buf) > 0 && (err == nil || isFrameSilence(err)) {
+				return buf, nil
+			}
+			return nil, ErrTimeout
+		}
+	}
+}
+
+// isFrameSilence reports whether err is the kind of Read error that
+// means "no more bytes arrived before the deadline" rather than a real
+// I/O failure: a read deadline timeout, or io.EOF from an in-memory
+// Pipe peer that stopped writing.
+func isFrameSilence(err error) bool {
+	return errors.Is(err, os.ErrDeadlineExceeded) || errors.Is(err, io.EOF)
+}
+
+func hasSuffix(buf, suffix []byte) bool {
+	if len(buf) < len(suffix) {
+		return false
+	}
+	for i := range suffix {
+		if buf[len(buf)-len(suffix)+i] != suffix[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// ReadCoils reads quantity coils starting at address.
+func (c *Client) ReadCoils(address, quantity uint16) ([]bool, error) {
+	return c.readBits(funcReadCoils, address, quantity)
+}
+
+// ReadDiscreteInputs reads quantity discrete inputs starting at address.
+func (c *Client) ReadDiscreteInputs(address, quantity uint16) ([]bool, error) {
+	return c.readBits(funcReadDiscreteInputs, address, quantity)
+}
+
+func (c *Client) readBits(fn byte, address, quantity uint16) ([]bool, error) {
+	resp, err := c.Send([]byte{fn, byte(address >> 8), byte(address), byte(quantity >> 8), byte(quantity)})
+	if err != nil {
+		return nil, err
+	}
+	if len(resp) < 2 {
+		return nil, ErrShortResponse
+	}
+	byteCount := int(resp[1])
+	need := (int(quantity) + 7) / 8
+	if byteCount < need || len(resp) < 2+byteCount {
+		return nil, ErrShortResponse
+	}
+	bits := make([]bool, quantity)
+	for i := 0; i < int(quantity); i++ {
+		bits[i] = resp[2+i/8]&(1<<uint(i%8)) != 0
+	}
+	return bits, nil
+}
+
+// ReadHoldingRegisters reads quantity 16-bit holding registers starting
+// at address.
+func (c *Client) ReadHoldingRegisters(address, quantity uint16) ([]uint16, error) {
+	return c.readRegisters(funcReadHoldingRegisters, address, quantity)
+}
+
+// ReadInputRegisters reads quantity 16-bit input registers starting at
+// address.
+func (c *Client) ReadInputRegisters(address, quantity uint16) ([]uint16, error) {
+	return c.readRegisters(funcReadInputRegisters, address, quantity)
+}
+
+func (c *Client) readRegisters(fn byte, address, quantity uint16) ([]uint16, error) {
+	resp, err := c.Send([]byte{fn, byte(address >> 8), byte(address), byte(quantity >> 8), byte(quantity)})
+	if err != nil {
+		return nil, err
+	}
+	if len(resp) < 1 || len(resp) < 2+2*int(quantity) {
+		return nil, ErrShortResponse
+	}
+	regs := make([]uint16, quantity)
+	for i := range regs {
+		regs[i] = uint16(resp[2+2*i])<<8 | uint16(resp[3+2*i])
+	}
+	return regs, nil
+}
+
+// WriteSingleCoil sets the coil at address to value.
+func (c *Client) WriteSingleCoil(address uint16, value bool) error {
+	v := uint16(coilOff)
+	if value {
+		v = coilOn
+	}
+	_, err := c.Send([]byte{funcWriteSingleCoil, byte(address >> 8), byte(address), byte(v >> 8), byte(v)})
+	return err
+}
+
+// WriteSingleRegister sets the holding register at address to value.
+func (c *Client) WriteSingleRegister(address, value uint16) error {
+	_, err := c.Send([]byte{funcWriteSingleRegister, byte(address >> 8), byte(address), byte(value >> 8), byte(value)})
+	return err
+}
+
+// WriteMultipleCoils sets the coils starting at address to values.
+func (c *Client) WriteMultipleCoils(address uint16, values []bool) error {
+	quantity := uint16(len(values))
+	byteCount := (len(values) + 7) / 8
+	data := make([]byte, byteCount)
+	for i, v := range values {
+		if v {
+			data[i/8] |= 1 << uint(i%8)
+		}
+	}
+	pdu := []byte{funcWriteMultipleCoils, byte(address >> 8), byte(address), byte(quantity >> 8), byte(quantity), byte(byteCount)}
+	_, err := c.Send(append(pdu, data...))
+	return err
+}
+
+// WriteMultipleRegisters sets the holding registers starting at address
+// to values.
+func (c *Client) WriteMultipleRegisters(address uint16, values []uint16) error {
+	quantity := uint16(len(values))
+	byteCount := len(values) * 2
+	pdu := []byte{funcWriteMultipleRegisters, byte(address >> 8), byte(address), byte(quantity >> 8), byte(quantity), byte(byteCount)}
+	for _, v := range values {
+		pdu = append(pdu, byte(v>>8), byte(v))
+	}
+	_, err := c.Send(pdu)
+	return err
+}
@@ -0,0 +1,80 @@
+package modbus
+
+import (
+	"encoding/binary"
+	"time"
+)
+
+// RTUHandler implements Handler using Modbus RTU framing:
+// [slave | function | data... | CRC-lo | CRC-hi], with frames
+// delimited by at least 3.5 character times of line silence rather
+// than an in-band terminator.
+type RTUHandler struct {
+	interFrameDelay time.Duration
+}
+
+// NewRTUHandler returns an RTUHandler whose inter-frame silence is
+// derived from baud, the serial line's bit rate. Per the Modbus
+// specification the silence must be at least 3.5 character times (a
+// character being 11 bits) at baud rates of 19200 and below; above
+// that a fixed 1750µs minimum is used instead, since the 3.5 character
+// time would otherwise be too short to reliably detect.
+func NewRTUHandler(baud int) *RTUHandler {
+	const minDelay = 1750 * time.Microsecond
+	if baud <= 0 || baud > 19200 {
+		return &RTUHandler{interFrameDelay: minDelay}
+	}
+	charTime := time.Second * 11 / time.Duration(baud)
+	delay := charTime * 35 / 10
+	if delay < minDelay {
+		delay = minDelay
+	}
+	return &RTUHandler{interFrameDelay: delay}
+}
+
+// InterFrameDelay returns the minimum silence required before and
+// between frames.
+func (h *RTUHandler) InterFrameDelay() time.Duration {
+	return h.interFrameDelay
+}
+
+func (h *RTUHandler) Terminator() []byte {
+	return nil
+}
+
+func (h *RTUHandler) Encode(slave byte, pdu []byte) []byte {
+	frame := make([]byte, 0, 1+len(pdu)+2)
+	frame = append(frame, slave)
+	frame = append(frame, pdu...)
+	crc := crc16(frame)
+	return append(frame, byte(crc), byte(crc>>8))
+}
+
+func (h *RTUHandler) Decode(frame []byte) (slave byte, pdu []byte, err error) {
+	if len(frame) < 4 {
+		return 0, nil, ErrShortResponse
+	}
+	data := frame[:len(frame)-2]
+	gotCRC := binary.LittleEndian.Uint16(frame[len(frame)-2:])
+	if crc16(data) != gotCRC {
+		return 0, nil, ErrFrame
+	}
+	return data[0], data[1:], nil
+}
+
+// crc16 computes the Modbus CRC16: polynomial 0xA001, initial value
+// 0xFFFF, reflected.
+func crc16(data []byte) uint16 {
+	crc := uint16(0xFFFF)
+	for _, b := range data {
+		crc ^= uint16(b)
+		for i := 0; i < 8; i++ {
+			if crc&1 != 0 {
+				crc = (crc >> 1) ^ 0xA001
+			} else {
+				crc >>= 1
+			}
+		}
+	}
+	return crc
+}
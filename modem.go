@@ -0,0 +1,21 @@
+package serial
+
+// ModemEvent is a snapshot of the modem status lines (as returned by
+// GetStatus) delivered by Port.ModemEvents whenever one of them
+// changes.
+type ModemEvent struct {
+	Status uint
+}
+
+// Modem status bits returned by GetStatus, matching the conventional
+// TIOCM_* values so that code written against one Transport's status
+// bitmask (e.g. a local Port's) also works against another (e.g. an
+// RFC 2217 connection's).
+const (
+	ModemDTR = 1 << 1
+	ModemRTS = 1 << 2
+	ModemCTS = 1 << 5
+	ModemCD  = 1 << 6
+	ModemRI  = 1 << 7
+	ModemDSR = 1 << 8
+)
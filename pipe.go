@@ -0,0 +1,42 @@
+package serial
+
+import (
+	"net"
+	"time"
+)
+
+// Pipe returns two Transports that are cross-connected in memory: data
+// written to one is readable from the other. It's meant for
+// unit-testing protocol code (such as package modbus) without needing
+// a real serial port.
+func Pipe() (Transport, Transport) {
+	a, b := net.Pipe()
+	return &memTransport{Conn: a}, &memTransport{Conn: b}
+}
+
+// memTransport adapts a net.Conn, as returned by net.Pipe, to the
+// Transport interface. There's no physical line to drive, so the
+// modem-control and break methods are no-ops.
+type memTransport struct {
+	net.Conn
+}
+
+func (m *memTransport) Flush() error {
+	return nil
+}
+
+func (m *memTransport) SendBreak(d time.Duration) error {
+	return nil
+}
+
+func (m *memTransport) SetDTR(v byte) error {
+	return nil
+}
+
+func (m *memTransport) SetRTS(v byte) error {
+	return nil
+}
+
+func (m *memTransport) GetStatus() (uint, error) {
+	return 0, nil
+}
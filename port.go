@@ -0,0 +1,54 @@
+package serial
+
+import (
+	"net/url"
+	"time"
+)
+
+// Config holds optional settings for OpenPort beyond the basic line
+// parameters, so new knobs can be added without growing OpenPort's
+// argument list again.
+type Config struct {
+	// AutoRTS puts the port into RS-485 half-duplex mode (driver-
+	// managed where the kernel supports it, userspace RTS toggling
+	// otherwise) as soon as it's opened, equivalent to calling
+	// Port.SetRS485 with RTSOnSend set by hand. It's a no-op on
+	// backends that don't implement SetRS485, such as a Pipe or an
+	// RFC 2217 connection. On darwin and windows, whose Port.SetRS485
+	// returns ErrNotSupported, OpenPort closes the port and returns
+	// that error.
+	AutoRTS bool
+}
+
+// OpenPort opens name for serial I/O at the given parameters and
+// returns a Transport. If name parses as a rfc2217:// or telnet://
+// URL it is opened as a networked RFC 2217 Com Port Control session
+// instead of a local device, via DialRFC2217; anything else (e.g.
+// /dev/ttyUSB0, COM3) is opened as before, unchanged for existing
+// callers.
+func OpenPort(name string, baud int, databits byte, parity Parity, stopbits StopBits, readTimeout time.Duration, cfg Config) (Transport, error) {
+	var t Transport
+	if u, err := url.Parse(name); err == nil && (u.Scheme == "rfc2217" || u.Scheme == "telnet") {
+		rt, err := DialRFC2217(u.Host, baud, databits, parity, stopbits)
+		if err != nil {
+			return nil, err
+		}
+		t = rt
+	} else {
+		p, err := openPort(name, baud, databits, parity, stopbits, readTimeout)
+		if err != nil {
+			return nil, err
+		}
+		t = p
+	}
+
+	if cfg.AutoRTS {
+		if rs485, ok := t.(interface{ SetRS485(RS485Config) error }); ok {
+			if err := rs485.SetRS485(RS485Config{Enabled: true, RTSOnSend: true}); err != nil {
+				t.Close()
+				return nil, err
+			}
+		}
+	}
+	return t, nil
+}
@@ -0,0 +1,336 @@
+package serial
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// Telnet protocol bytes used to negotiate and carry the RFC 2217 Com
+// Port Control option.
+const (
+	telnetIAC  = 255
+	telnetSB   = 250
+	telnetSE   = 240
+	telnetWILL = 251
+	telnetWONT = 252
+	telnetDO   = 253
+	telnetDONT = 254
+
+	comPortOption = 44
+)
+
+// RFC 2217 Com Port Control subcommands (RFC 2217 section 8). Server
+// replies use the same values plus serverOffset.
+const (
+	scSetBaudrate      = 1
+	scSetDatasize      = 2
+	scSetParity        = 3
+	scSetStopsize      = 4
+	scSetControl       = 5
+	scNotifyModemstate = 7
+	serverOffset       = 100
+)
+
+// SET-CONTROL values (RFC 2217 section 6).
+const (
+	ctlBreakOn  = 5
+	ctlBreakOff = 6
+	ctlDTROn    = 8
+	ctlDTROff   = 9
+	ctlRTSOn    = 11
+	ctlRTSOff   = 12
+)
+
+// rfc2217Transport implements Transport by speaking the RFC 2217
+// Telnet Com Port Control extension to a networked serial server
+// (e.g. ser2net, a MOXA NPort).
+type rfc2217Transport struct {
+	conn net.Conn
+
+	dataR *io.PipeReader
+	dataW *io.PipeWriter
+
+	modemStateCh chan struct{}
+
+	mu         sync.Mutex
+	modemState uint
+}
+
+// DialRFC2217 connects to the RFC 2217 server at addr (host:port) and
+// configures its port to the given parameters, returning a Transport
+// that behaves like a local Port.
+func DialRFC2217(addr string, baud int, databits byte, parity Parity, stopbits StopBits) (Transport, error) {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	dataR, dataW := io.Pipe()
+	t := &rfc2217Transport{
+		conn:         conn,
+		dataR:        dataR,
+		dataW:        dataW,
+		modemStateCh: make(chan struct{}, 1),
+	}
+
+	// Offer and request the Com Port Control option. We don't block on
+	// the server's WILL/DO reply, and the subnegotiations below don't
+	// wait for a reply either: a server that doesn't understand the
+	// option is not detected here, and only errors writing to the
+	// connection are returned.
+	if _, err := conn.Write([]byte{
+		telnetIAC, telnetWILL, comPortOption,
+		telnetIAC, telnetDO, comPortOption,
+	}); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	go t.demux()
+
+	if err := t.sendControl(scSetBaudrate, byte(baud>>24), byte(baud>>16), byte(baud>>8), byte(baud)); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if err := t.sendControl(scSetDatasize, databits); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	asciiParity, err := rfc2217Parity(parity)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if err := t.sendControl(scSetParity, asciiParity); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	asciiStop, err := rfc2217StopBits(stopbits)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if err := t.sendControl(scSetStopsize, asciiStop); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return t, nil
+}
+
+func rfc2217Parity(p Parity) (byte, error) {
+	switch p {
+	case ParityNone:
+		return 1, nil
+	case ParityOdd:
+		return 2, nil
+	case ParityEven:
+		return 3, nil
+	case ParityMark:
+		return 4, nil
+	default:
+		return 0, ErrBadParity
+	}
+}
+
+func rfc2217StopBits(s StopBits) (byte, error) {
+	switch s {
+	case Stop1:
+		return 1, nil
+	case Stop2:
+		return 2, nil
+	default:
+		return 0, ErrBadStopBits
+	}
+}
+
+// sendControl writes an IAC SB COM-PORT-OPTION <subcommand> <data...>
+// IAC SE subnegotiation, escaping any 0xFF byte in data as IAC IAC.
+func (t *rfc2217Transport) sendControl(subcommand byte, data ...byte) error {
+	var buf bytes.Buffer
+	buf.Write([]byte{telnetIAC, telnetSB, comPortOption, subcommand})
+	for _, b := range data {
+		buf.WriteByte(b)
+		if b == telnetIAC {
+			buf.WriteByte(telnetIAC)
+		}
+	}
+	buf.Write([]byte{telnetIAC, telnetSE})
+	_, err := t.conn.Write(buf.Bytes())
+	return err
+}
+
+// demux reads the raw telnet stream off the connection, stripping out
+// IAC sequences so that Read only ever sees plain data bytes, and
+// dispatches Com Port Control subnegotiations (currently just
+// NOTIFY-MODEMSTATE) to handleSubnegotiation.
+func (t *rfc2217Transport) demux() {
+	const (
+		stData = iota
+		stIAC
+		stOption
+		stSB
+		stSBIAC
+	)
+	defer t.dataW.Close()
+
+	state := stData
+	var sb []byte
+	raw := make([]byte, 256)
+	for {
+		n, err := t.conn.Read(raw)
+		for i := 0; i < n; i++ {
+			b := raw[i]
+			switch state {
+			case stData:
+				if b == telnetIAC {
+					state = stIAC
+				} else {
+					t.dataW.Write([]byte{b})
+				}
+			case stIAC:
+				switch b {
+				case telnetIAC:
+					t.dataW.Write([]byte{telnetIAC})
+					state = stData
+				case telnetSB:
+					sb = sb[:0]
+					state = stSB
+				case telnetWILL, telnetWONT, telnetDO, telnetDONT:
+					state = stOption
+				default:
+					state = stData
+				}
+			case stOption:
+				// The option byte of a WILL/WONT/DO/DONT we don't act
+				// on beyond what DialRFC2217 already negotiated.
+				state = stData
+			case stSB:
+				if b == telnetIAC {
+					state = stSBIAC
+				} else {
+					sb = append(sb, b)
+				}
+			case stSBIAC:
+				switch b {
+				case telnetIAC:
+					sb = append(sb, telnetIAC)
+					state = stSB
+				case telnetSE:
+					t.handleSubnegotiation(sb)
+					state = stData
+				default:
+					state = stData
+				}
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (t *rfc2217Transport) handleSubnegotiation(sb []byte) {
+	if len(sb) < 3 || sb[0] != comPortOption || sb[1] != scNotifyModemstate+serverOffset {
+		return
+	}
+	t.mu.Lock()
+	t.modemState = rfc2217ModemBits(sb[2])
+	t.mu.Unlock()
+	select {
+	case t.modemStateCh <- struct{}{}:
+	default:
+	}
+}
+
+// rfc2217ModemBits maps an RFC 2217 modem-state byte (bits 4-7: CTS,
+// DSR, RI, CD; bits 0-3 are "changed since last report" deltas we
+// don't need) onto this package's Modem* bits.
+func rfc2217ModemBits(state byte) uint {
+	var m uint
+	if state&0x10 != 0 {
+		m |= ModemCTS
+	}
+	if state&0x20 != 0 {
+		m |= ModemDSR
+	}
+	if state&0x40 != 0 {
+		m |= ModemRI
+	}
+	if state&0x80 != 0 {
+		m |= ModemCD
+	}
+	return m
+}
+
+func (t *rfc2217Transport) Read(b []byte) (int, error) {
+	return t.dataR.Read(b)
+}
+
+func (t *rfc2217Transport) Write(b []byte) (int, error) {
+	var buf bytes.Buffer
+	for _, c := range b {
+		buf.WriteByte(c)
+		if c == telnetIAC {
+			buf.WriteByte(telnetIAC)
+		}
+	}
+	if _, err := t.conn.Write(buf.Bytes()); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+func (t *rfc2217Transport) Close() error {
+	return t.conn.Close()
+}
+
+// Flush is a no-op: RFC 2217's PURGE-DATA subcommand purges the
+// server's local buffers, which isn't meaningful for the TCP
+// connection itself.
+func (t *rfc2217Transport) Flush() error {
+	return nil
+}
+
+func (t *rfc2217Transport) SendBreak(d time.Duration) error {
+	if err := t.sendControl(scSetControl, ctlBreakOn); err != nil {
+		return err
+	}
+	time.Sleep(d)
+	return t.sendControl(scSetControl, ctlBreakOff)
+}
+
+func (t *rfc2217Transport) SetDTR(v byte) error {
+	if v != 0 {
+		return t.sendControl(scSetControl, ctlDTROn)
+	}
+	return t.sendControl(scSetControl, ctlDTROff)
+}
+
+func (t *rfc2217Transport) SetRTS(v byte) error {
+	if v != 0 {
+		return t.sendControl(scSetControl, ctlRTSOn)
+	}
+	return t.sendControl(scSetControl, ctlRTSOff)
+}
+
+// GetStatus requests a NOTIFY-MODEMSTATE update from the server and
+// returns what it last reported, mapped onto the Modem* bits. If the
+// server doesn't reply within 2 seconds, the last known state (zero
+// values if none has ever arrived) is returned instead of blocking
+// forever.
+func (t *rfc2217Transport) GetStatus() (uint, error) {
+	if err := t.sendControl(scNotifyModemstate); err != nil {
+		return 0, err
+	}
+	select {
+	case <-t.modemStateCh:
+	case <-time.After(2 * time.Second):
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.modemState, nil
+}
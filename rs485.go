@@ -0,0 +1,26 @@
+package serial
+
+// RS485Config configures RS-485 half-duplex operation, where RTS is
+// toggled to enable a transceiver's driver for the duration of each
+// transmission and is otherwise left receiving.
+type RS485Config struct {
+	// Enabled turns RS-485 mode on. When false, SetRS485 restores the
+	// port to normal RS-232 full-duplex operation and the remaining
+	// fields are ignored.
+	Enabled bool
+	// RTSOnSend is the RTS level to drive while transmitting.
+	RTSOnSend bool
+	// RTSAfterSend is the RTS level to restore once transmission has
+	// finished.
+	RTSAfterSend bool
+	// DelayBeforeSendUsec is how long to hold RTS before the first bit
+	// goes out, giving the transceiver time to switch to transmit.
+	DelayBeforeSendUsec uint32
+	// DelayAfterSendUsec is how long to hold the bus after the last
+	// bit before RTS is restored, so the final character isn't
+	// clipped.
+	DelayAfterSendUsec uint32
+	// RxDuringTx keeps the receiver enabled while transmitting, which
+	// some multi-drop setups use for echo/collision detection.
+	RxDuringTx bool
+}
@@ -0,0 +1,238 @@
+//go:build darwin
+// +build darwin
+
+package serial
+
+import (
+	"context"
+	"os"
+	"sync"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// IOSSIOSPEED (from <IOKit/serial/ioss.h>) sets an arbitrary baud rate
+// on the line, bypassing the fixed Bxxxxx speed table.
+const ioctlIOSSIOSPEED = 0x80045402
+
+func openPort(name string, baud int, databits byte, parity Parity, stopbits StopBits, readTimeout time.Duration) (p *Port, err error) {
+	f, err := os.OpenFile(name, unix.O_RDWR|unix.O_NOCTTY|unix.O_NONBLOCK, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil && f != nil {
+			f.Close()
+		}
+	}()
+
+	t, err := unix.IoctlGetTermios(int(f.Fd()), unix.TIOCGETA)
+	if err != nil {
+		return nil, err
+	}
+
+	t.Cflag = unix.CREAD | unix.CLOCAL | unix.HUPCL
+	switch databits {
+	case 5:
+		t.Cflag |= unix.CS5
+	case 6:
+		t.Cflag |= unix.CS6
+	case 7:
+		t.Cflag |= unix.CS7
+	case 8:
+		t.Cflag |= unix.CS8
+	default:
+		return nil, ErrBadSize
+	}
+	switch stopbits {
+	case Stop1:
+		// default is 1 stop bit
+	case Stop2:
+		t.Cflag |= unix.CSTOPB
+	default:
+		return nil, ErrBadStopBits
+	}
+	switch parity {
+	case ParityNone:
+		// default is no parity
+	case ParityOdd:
+		t.Cflag |= unix.PARENB
+		t.Cflag |= unix.PARODD
+	case ParityEven:
+		t.Cflag |= unix.PARENB
+	default:
+		return nil, ErrBadParity
+	}
+	t.Iflag = unix.IGNPAR
+	t.Oflag = 0
+	t.Lflag = 0
+	vmin, vtime := posixTimeoutValues(readTimeout)
+	t.Cc[unix.VMIN] = vmin
+	t.Cc[unix.VTIME] = vtime
+
+	if err := unix.IoctlSetTermios(int(f.Fd()), unix.TIOCSETA, t); err != nil {
+		return nil, err
+	}
+
+	speed := uint32(baud)
+	if _, _, errno := unix.Syscall(
+		unix.SYS_IOCTL,
+		f.Fd(),
+		uintptr(ioctlIOSSIOSPEED),
+		uintptr(unsafe.Pointer(&speed)),
+	); errno != 0 {
+		return nil, errno
+	}
+
+	return &Port{f: f, closed: make(chan struct{})}, nil
+}
+
+type Port struct {
+	// We intentionly do not use an "embedded" struct so that we
+	// don't export File
+	f *os.File
+
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+func (p *Port) Read(b []byte) (n int, err error) {
+	return p.f.Read(b)
+}
+
+func (p *Port) Write(b []byte) (n int, err error) {
+	return p.f.Write(b)
+}
+
+func (p *Port) Close() (err error) {
+	p.closeOnce.Do(func() { close(p.closed) })
+	return p.f.Close()
+}
+
+// FREAD/FWRITE (from <sys/file.h>) aren't exposed by
+// golang.org/x/sys/unix on darwin, so the flag values TIOCFLUSH wants
+// are given literally here.
+const (
+	fread  = 0x1
+	fwrite = 0x2
+)
+
+// Discards data written to the port but not transmitted, or data
+// received but not read.
+func (p *Port) Flush() error {
+	return unix.IoctlSetInt(int(p.f.Fd()), unix.TIOCFLUSH, fread|fwrite)
+}
+
+// SendBreak sends a break (bus low value) for the given duration by
+// asserting TIOCSBRK, sleeping, and clearing it with TIOCCBRK.
+func (p *Port) SendBreak(d time.Duration) error {
+	if d <= 0 {
+		d = 300 * time.Millisecond
+	}
+	if err := unix.IoctlSetInt(int(p.f.Fd()), unix.TIOCSBRK, 0); err != nil {
+		return err
+	}
+	time.Sleep(d)
+	return unix.IoctlSetInt(int(p.f.Fd()), unix.TIOCCBRK, 0)
+}
+
+func (p *Port) GetStatus() (n uint, err error) {
+	status, err := unix.IoctlGetInt(int(p.f.Fd()), unix.TIOCMGET)
+	return uint(status), err
+}
+
+func (p *Port) SetDTR(v byte) error {
+	return p.setModemBit(unix.TIOCM_DTR, v)
+}
+
+func (p *Port) SetRTS(v byte) error {
+	return p.setModemBit(unix.TIOCM_RTS, v)
+}
+
+func (p *Port) setModemBit(bit int, v byte) error {
+	req := unix.TIOCMBIS
+	if v == 0 {
+		req = unix.TIOCMBIC
+	}
+	return unix.IoctlSetPointerInt(int(p.f.Fd()), uint(req), bit)
+}
+
+// SetBaud reconfigures the line to baud without closing and reopening
+// the port, using the IOSSIOSPEED ioctl so arbitrary integer baud
+// rates are supported, not just the ones with a termios Bxxxxx
+// constant.
+func (p *Port) SetBaud(baud int) error {
+	if baud <= 0 {
+		return ErrBadBaud
+	}
+	speed := uint32(baud)
+	if _, _, errno := unix.Syscall(
+		unix.SYS_IOCTL,
+		p.f.Fd(),
+		uintptr(ioctlIOSSIOSPEED),
+		uintptr(unsafe.Pointer(&speed)),
+	); errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// Baud returns the line's current baud rate, as read back from the
+// termios input speed.
+func (p *Port) Baud() (int, error) {
+	t, err := unix.IoctlGetTermios(int(p.f.Fd()), unix.TIOCGETA)
+	if err != nil {
+		return 0, err
+	}
+	return int(t.Ispeed), nil
+}
+
+// WaitForModemChange is not implemented on this platform.
+func (p *Port) WaitForModemChange(ctx context.Context, mask uint) (uint, error) {
+	return 0, ErrNotSupported
+}
+
+// ModemEvents is not implemented on this platform; it returns a
+// channel that is immediately closed.
+func (p *Port) ModemEvents() <-chan ModemEvent {
+	ch := make(chan ModemEvent)
+	close(ch)
+	return ch
+}
+
+// SetRS485 is not implemented on this platform.
+func (p *Port) SetRS485(cfg RS485Config) error {
+	return ErrNotSupported
+}
+
+// SetReadDeadline is not implemented on this platform.
+func (p *Port) SetReadDeadline(t time.Time) error {
+	return ErrNotSupported
+}
+
+// SetWriteDeadline is not implemented on this platform.
+func (p *Port) SetWriteDeadline(t time.Time) error {
+	return ErrNotSupported
+}
+
+// SetDeadline is not implemented on this platform.
+func (p *Port) SetDeadline(t time.Time) error {
+	return ErrNotSupported
+}
+
+// resetReadDeadline is a no-op on this platform: deadlines aren't
+// implemented here, so ReadContext's SetReadDeadline(time.Now()) to
+// unblock a cancelled Read is itself already a no-op with nothing to
+// undo.
+func (p *Port) resetReadDeadline() error {
+	return nil
+}
+
+// resetWriteDeadline is a no-op on this platform, for the same reason
+// as resetReadDeadline.
+func (p *Port) resetWriteDeadline() error {
+	return nil
+}
@@ -3,7 +3,9 @@
 package serial
 
 import (
+	"context"
 	"os"
+	"sync"
 	"syscall"
 	"time"
 	"unsafe"
@@ -97,27 +99,153 @@ func openPort(name string, baud int, databits byte, parity Parity, stopbits Stop
 		return nil, errno
 	}
 
-	if err = unix.SetNonblock(int(fd), false); err != nil {
-		return
-	}
+	// The fd is left non-blocking (it was opened with O_NONBLOCK above)
+	// so that Go's runtime poller manages it; that's what lets
+	// SetReadDeadline/SetWriteDeadline/SetDeadline work below, instead
+	// of Read/Write blocking purely on the VMIN/VTIME settings. VTIME
+	// is set above for the benefit of anyone who later reopens the fd
+	// outside this package, but Read itself now gets its default
+	// per-call timeout from readTimeout via a deadline (see Read).
 
-	return &Port{f: f}, nil
+	return &Port{f: f, closed: make(chan struct{}), readTimeout: readTimeout}, nil
 }
 
 type Port struct {
 	// We intentionly do not use an "embedded" struct so that we
 	// don't export File
 	f *os.File
+
+	closeOnce sync.Once
+	closed    chan struct{}
+
+	// readTimeout is the default per-Read timeout from openPort's
+	// readTimeout argument. Read applies it as a fresh deadline before
+	// every call, standing in for the old VMIN/VTIME-only timeout, up
+	// until the caller takes over deadline management explicitly via
+	// SetReadDeadline/SetDeadline, at which point customDeadline
+	// disables this default. deadlineMu guards customDeadline, since
+	// Read and SetReadDeadline/SetDeadline run on different goroutines
+	// when driven via ReadContext.
+	readTimeout    time.Duration
+	deadlineMu     sync.Mutex
+	customDeadline bool
+
+	// rs485 and rs485Userspace are only set when SetRS485 falls back
+	// to toggling RTS in software because the kernel driver has no
+	// TIOCSRS485 support.
+	rs485          RS485Config
+	rs485Userspace bool
 }
 
+// Read reads from the port, honoring readTimeout (from openPort) as a
+// default deadline: if the caller hasn't called SetReadDeadline or
+// SetDeadline themselves, Read returns an error wrapping
+// os.ErrDeadlineExceeded once readTimeout elapses with no data, rather
+// than blocking forever.
 func (p *Port) Read(b []byte) (n int, err error) {
+	p.deadlineMu.Lock()
+	custom := p.customDeadline
+	p.deadlineMu.Unlock()
+	if p.readTimeout > 0 && !custom {
+		if err := p.f.SetReadDeadline(time.Now().Add(p.readTimeout)); err != nil {
+			return 0, err
+		}
+	}
 	return p.f.Read(b)
 }
 
+// SetReadDeadline sets the deadline for future Read calls, with
+// net.Conn semantics: a zero Time means Read never times out. Calling
+// this disables the default readTimeout deadline in favor of the
+// caller's own deadline management.
+func (p *Port) SetReadDeadline(t time.Time) error {
+	p.deadlineMu.Lock()
+	p.customDeadline = true
+	p.deadlineMu.Unlock()
+	return p.f.SetReadDeadline(t)
+}
+
+// SetWriteDeadline sets the deadline for future Write calls, with
+// net.Conn semantics: a zero Time means Write never times out.
+func (p *Port) SetWriteDeadline(t time.Time) error {
+	return p.f.SetWriteDeadline(t)
+}
+
+// SetDeadline sets both the read and write deadlines, with net.Conn
+// semantics: a zero Time means neither times out. Calling this
+// disables the default readTimeout deadline in favor of the caller's
+// own deadline management.
+func (p *Port) SetDeadline(t time.Time) error {
+	p.deadlineMu.Lock()
+	p.customDeadline = true
+	p.deadlineMu.Unlock()
+	return p.f.SetDeadline(t)
+}
+
+// resetReadDeadline undoes the one-shot SetReadDeadline(time.Now())
+// that ReadContext uses to unblock a cancelled Read: it clears the
+// deadline and re-enables the default per-call readTimeout deadline,
+// so the port isn't left permanently timing out.
+func (p *Port) resetReadDeadline() error {
+	p.deadlineMu.Lock()
+	p.customDeadline = false
+	p.deadlineMu.Unlock()
+	return p.f.SetReadDeadline(time.Time{})
+}
+
+// resetWriteDeadline undoes the one-shot SetWriteDeadline(time.Now())
+// that WriteContext uses to unblock a cancelled Write.
+func (p *Port) resetWriteDeadline() error {
+	return p.f.SetWriteDeadline(time.Time{})
+}
+
 func (p *Port) Write(b []byte) (n int, err error) {
+	if p.rs485Userspace {
+		return p.writeRS485(b)
+	}
 	return p.f.Write(b)
 }
 
+// writeRS485 asserts RTS, writes b, drains the output (via TCSBRK with
+// arg 1, POSIX's tcdrain) so the UART has actually finished shifting
+// the bytes out, then restores RTS, honoring the configured
+// before/after send delays.
+func (p *Port) writeRS485(b []byte) (n int, err error) {
+	if err = p.SetRTS(boolToBit(p.rs485.RTSOnSend)); err != nil {
+		return 0, err
+	}
+	if p.rs485.DelayBeforeSendUsec > 0 {
+		time.Sleep(time.Duration(p.rs485.DelayBeforeSendUsec) * time.Microsecond)
+	}
+
+	n, err = p.f.Write(b)
+	if err == nil {
+		if _, _, errno := unix.Syscall(
+			unix.SYS_IOCTL,
+			p.f.Fd(),
+			uintptr(unix.TCSBRK),
+			1,
+		); errno != 0 {
+			err = errno
+		}
+	}
+
+	if p.rs485.DelayAfterSendUsec > 0 {
+		time.Sleep(time.Duration(p.rs485.DelayAfterSendUsec) * time.Microsecond)
+	}
+	if rtsErr := p.SetRTS(boolToBit(p.rs485.RTSAfterSend)); err == nil {
+		err = rtsErr
+	}
+	return n, err
+}
+
+func boolToBit(v bool) byte {
+	if v {
+		return 1
+	}
+	return 0
+}
+
 // Discards data written to the port but not transmitted,
 // or data received but not read
 func (p *Port) Flush() error {
@@ -206,6 +334,184 @@ func (p *Port) SetRTS(v byte) (err error) {
 	}
 }
 
+// linuxSerialRS485 mirrors struct serial_rs485 from <linux/serial.h>.
+type linuxSerialRS485 struct {
+	Flags              uint32
+	DelayRTSBeforeSend uint32
+	DelayRTSAfterSend  uint32
+	Padding            [5]uint32
+}
+
+const (
+	tiocsrs485 = 0x542F
+
+	serRS485Enabled      = 1 << 0
+	serRS485RTSOnSend    = 1 << 1
+	serRS485RTSAfterSend = 1 << 2
+	serRS485RxDuringTx   = 1 << 4
+)
+
+// SetRS485 puts the port into RS-485 half-duplex mode as described by
+// cfg. Where the kernel UART driver supports it, bus turnaround is
+// handled entirely by the driver via the TIOCSRS485 ioctl; otherwise
+// SetRS485 falls back to toggling RTS around each Write itself.
+func (p *Port) SetRS485(cfg RS485Config) error {
+	if !cfg.Enabled {
+		p.rs485Userspace = false
+		var raw linuxSerialRS485
+		_, _, errno := unix.Syscall(unix.SYS_IOCTL, p.f.Fd(), uintptr(tiocsrs485), uintptr(unsafe.Pointer(&raw)))
+		if errno != 0 && errno != syscall.ENOTTY {
+			return errno
+		}
+		return nil
+	}
+
+	raw := linuxSerialRS485{
+		Flags:              serRS485Enabled,
+		DelayRTSBeforeSend: cfg.DelayBeforeSendUsec / 1000,
+		DelayRTSAfterSend:  cfg.DelayAfterSendUsec / 1000,
+	}
+	if cfg.RTSOnSend {
+		raw.Flags |= serRS485RTSOnSend
+	}
+	if cfg.RTSAfterSend {
+		raw.Flags |= serRS485RTSAfterSend
+	}
+	if cfg.RxDuringTx {
+		raw.Flags |= serRS485RxDuringTx
+	}
+
+	if _, _, errno := unix.Syscall(unix.SYS_IOCTL, p.f.Fd(), uintptr(tiocsrs485), uintptr(unsafe.Pointer(&raw))); errno == 0 {
+		p.rs485Userspace = false
+		return nil
+	} else if errno != syscall.ENOTTY && errno != syscall.EINVAL {
+		return errno
+	}
+
+	// The adapter's driver has no TIOCSRS485 support; manage bus
+	// turnaround ourselves on every Write instead.
+	p.rs485 = cfg
+	p.rs485Userspace = true
+	return nil
+}
+
 func (p *Port) Close() (err error) {
+	p.closeOnce.Do(func() { close(p.closed) })
 	return p.f.Close()
 }
+
+// WaitForModemChange blocks until one of the modem status lines in
+// mask (unix.TIOCM_CTS, TIOCM_DSR, TIOCM_RI, TIOCM_CD) changes, ctx is
+// cancelled, or the port is closed, and returns the new status
+// snapshot. It is implemented with TIOCMIWAIT, which is far cheaper
+// than polling GetStatus.
+//
+// TIOCMIWAIT itself has no way to be interrupted from another
+// goroutine: cancelling ctx makes this call return immediately, but
+// the goroutine it spawned to perform the ioctl stays blocked in the
+// kernel until the line actually changes or the port is closed
+// (Close's f.Close unblocks it with an error). A caller that cancels
+// a long-lived ctx without ever closing the port will accumulate one
+// blocked goroutine per cancelled call; ModemEvents avoids this by
+// using context.Background() and relying on Close instead.
+func (p *Port) WaitForModemChange(ctx context.Context, mask uint) (uint, error) {
+	done := make(chan error, 1)
+	go func() {
+		_, _, errno := unix.Syscall(
+			unix.SYS_IOCTL,
+			p.f.Fd(),
+			uintptr(unix.TIOCMIWAIT),
+			uintptr(mask),
+		)
+		if errno != 0 {
+			done <- errno
+			return
+		}
+		done <- nil
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return 0, err
+		}
+		return p.GetStatus()
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	case <-p.closed:
+		return 0, ErrPortClosed
+	}
+}
+
+// ModemEvents returns a channel that receives a ModemEvent every time
+// one of the CTS/DSR/RI/DCD lines changes. It spawns a goroutine that
+// repeatedly calls WaitForModemChange; the channel is closed when the
+// port is closed.
+func (p *Port) ModemEvents() <-chan ModemEvent {
+	ch := make(chan ModemEvent)
+	mask := uint(unix.TIOCM_CTS | unix.TIOCM_DSR | unix.TIOCM_RI | unix.TIOCM_CD)
+	go func() {
+		defer close(ch)
+		for {
+			status, err := p.WaitForModemChange(context.Background(), mask)
+			if err != nil {
+				return
+			}
+			select {
+			case ch <- ModemEvent{Status: status}:
+			case <-p.closed:
+				return
+			}
+		}
+	}()
+	return ch
+}
+
+// SetBaud reconfigures the line to baud without closing and reopening
+// the port. Because the port is always opened with BOTHER, any
+// positive integer baud rate the underlying UART/adapter accepts can
+// be used here, not just one of the termios Bxxxxx constants.
+func (p *Port) SetBaud(baud int) error {
+	if baud <= 0 {
+		return ErrBadBaud
+	}
+	fd := p.f.Fd()
+	var t unix.Termios
+	if _, _, errno := unix.Syscall(
+		unix.SYS_IOCTL,
+		uintptr(fd),
+		uintptr(unix.TCGETS2),
+		uintptr(unsafe.Pointer(&t)),
+	); errno != 0 {
+		return errno
+	}
+
+	t.Cflag = (t.Cflag &^ unix.CBAUD) | unix.BOTHER
+	t.Ispeed = uint32(baud)
+	t.Ospeed = uint32(baud)
+
+	if _, _, errno := unix.Syscall(
+		unix.SYS_IOCTL,
+		uintptr(fd),
+		uintptr(unix.TCSETS2),
+		uintptr(unsafe.Pointer(&t)),
+	); errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// Baud returns the line's current output baud rate, as read back from
+// the kernel via TCGETS2.
+func (p *Port) Baud() (int, error) {
+	var t unix.Termios
+	if _, _, errno := unix.Syscall(
+		unix.SYS_IOCTL,
+		uintptr(p.f.Fd()),
+		uintptr(unix.TCGETS2),
+		uintptr(unsafe.Pointer(&t)),
+	); errno != 0 {
+		return 0, errno
+	}
+	return int(t.Ospeed), nil
+}
@@ -0,0 +1,316 @@
+//go:build windows
+// +build windows
+
+package serial
+
+import (
+	"context"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+var (
+	modkernel32            = syscall.NewLazyDLL("kernel32.dll")
+	procGetCommState       = modkernel32.NewProc("GetCommState")
+	procSetCommState       = modkernel32.NewProc("SetCommState")
+	procSetCommTimeouts    = modkernel32.NewProc("SetCommTimeouts")
+	procPurgeComm          = modkernel32.NewProc("PurgeComm")
+	procSetCommBreak       = modkernel32.NewProc("SetCommBreak")
+	procClearCommBreak     = modkernel32.NewProc("ClearCommBreak")
+	procGetCommModemStatus = modkernel32.NewProc("GetCommModemStatus")
+	procEscapeCommFunction = modkernel32.NewProc("EscapeCommFunction")
+)
+
+const (
+	purgeTXAbort = 0x0001
+	purgeRXAbort = 0x0002
+	purgeTXClear = 0x0004
+	purgeRXClear = 0x0008
+
+	setDTR = 5 // SETDTR
+	clrDTR = 6 // CLRDTR
+	setRTS = 3 // SETRTS
+	clrRTS = 4 // CLRRTS
+
+	msCTSOn  = 0x0010
+	msDSROn  = 0x0020
+	msRingOn = 0x0040
+	msRLSDOn = 0x0080
+
+	maxUint32 = 0xFFFFFFFF
+)
+
+// commTimeouts mirrors the Win32 COMMTIMEOUTS struct used by
+// SetCommTimeouts to control how ReadFile blocks.
+type commTimeouts struct {
+	ReadIntervalTimeout         uint32
+	ReadTotalTimeoutMultiplier  uint32
+	ReadTotalTimeoutConstant    uint32
+	WriteTotalTimeoutMultiplier uint32
+	WriteTotalTimeoutConstant   uint32
+}
+
+func openPort(name string, baud int, databits byte, parity Parity, stopbits StopBits, readTimeout time.Duration) (p *Port, err error) {
+	pathPtr, err := syscall.UTF16PtrFromString(name)
+	if err != nil {
+		return nil, err
+	}
+	h, err := syscall.CreateFile(
+		pathPtr,
+		syscall.GENERIC_READ|syscall.GENERIC_WRITE,
+		0,
+		nil,
+		syscall.OPEN_EXISTING,
+		syscall.FILE_ATTRIBUTE_NORMAL,
+		0,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			syscall.CloseHandle(h)
+		}
+	}()
+
+	var d dcb
+	d.DCBlength = uint32(unsafe.Sizeof(d))
+	if ret, _, err := procGetCommState.Call(uintptr(h), uintptr(unsafe.Pointer(&d))); ret == 0 {
+		return nil, err
+	}
+	d.BaudRate = uint32(baud)
+	d.ByteSize = databits
+	switch stopbits {
+	case Stop1:
+		d.StopBits = 0
+	case Stop2:
+		d.StopBits = 2
+	default:
+		return nil, ErrBadStopBits
+	}
+	switch parity {
+	case ParityNone:
+		d.Parity = 0
+	case ParityOdd:
+		d.Parity = 1
+	case ParityEven:
+		d.Parity = 2
+	default:
+		return nil, ErrBadParity
+	}
+	if ret, _, err := procSetCommState.Call(uintptr(h), uintptr(unsafe.Pointer(&d))); ret == 0 {
+		return nil, err
+	}
+
+	var timeouts commTimeouts
+	if readTimeout > 0 {
+		timeouts.ReadTotalTimeoutConstant = uint32(readTimeout.Milliseconds())
+	} else {
+		timeouts.ReadIntervalTimeout = maxUint32
+	}
+	if ret, _, err := procSetCommTimeouts.Call(uintptr(h), uintptr(unsafe.Pointer(&timeouts))); ret == 0 {
+		return nil, err
+	}
+
+	return &Port{handle: h, closed: make(chan struct{})}, nil
+}
+
+type Port struct {
+	handle syscall.Handle
+
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+func (p *Port) Read(b []byte) (n int, err error) {
+	var done uint32
+	if err := syscall.ReadFile(p.handle, b, &done, nil); err != nil {
+		return int(done), err
+	}
+	return int(done), nil
+}
+
+func (p *Port) Write(b []byte) (n int, err error) {
+	var done uint32
+	if err := syscall.WriteFile(p.handle, b, &done, nil); err != nil {
+		return int(done), err
+	}
+	return int(done), nil
+}
+
+func (p *Port) Close() (err error) {
+	p.closeOnce.Do(func() { close(p.closed) })
+	return syscall.CloseHandle(p.handle)
+}
+
+// Flush discards data written to the port but not transmitted, or
+// data received but not read, via PurgeComm.
+func (p *Port) Flush() error {
+	ret, _, err := procPurgeComm.Call(uintptr(p.handle), uintptr(purgeTXAbort|purgeRXAbort|purgeTXClear|purgeRXClear))
+	if ret == 0 {
+		return err
+	}
+	return nil
+}
+
+// SendBreak sends a break for the given duration using
+// SetCommBreak/ClearCommBreak.
+func (p *Port) SendBreak(d time.Duration) error {
+	if d <= 0 {
+		d = 300 * time.Millisecond
+	}
+	if ret, _, err := procSetCommBreak.Call(uintptr(p.handle)); ret == 0 {
+		return err
+	}
+	time.Sleep(d)
+	if ret, _, err := procClearCommBreak.Call(uintptr(p.handle)); ret == 0 {
+		return err
+	}
+	return nil
+}
+
+// GetStatus returns the modem status lines via GetCommModemStatus,
+// translated to the TIOCM_*-equivalent bits in package serial.
+func (p *Port) GetStatus() (n uint, err error) {
+	var ms uint32
+	if ret, _, err := procGetCommModemStatus.Call(uintptr(p.handle), uintptr(unsafe.Pointer(&ms))); ret == 0 {
+		return 0, err
+	}
+	var status uint
+	if ms&msCTSOn != 0 {
+		status |= ModemCTS
+	}
+	if ms&msDSROn != 0 {
+		status |= ModemDSR
+	}
+	if ms&msRingOn != 0 {
+		status |= ModemRI
+	}
+	if ms&msRLSDOn != 0 {
+		status |= ModemCD
+	}
+	return status, nil
+}
+
+func (p *Port) SetDTR(v byte) error {
+	fn := setDTR
+	if v == 0 {
+		fn = clrDTR
+	}
+	if ret, _, err := procEscapeCommFunction.Call(uintptr(p.handle), uintptr(fn)); ret == 0 {
+		return err
+	}
+	return nil
+}
+
+func (p *Port) SetRTS(v byte) error {
+	fn := setRTS
+	if v == 0 {
+		fn = clrRTS
+	}
+	if ret, _, err := procEscapeCommFunction.Call(uintptr(p.handle), uintptr(fn)); ret == 0 {
+		return err
+	}
+	return nil
+}
+
+// dcb mirrors the Win32 DCB struct field for field; the flags
+// bitfield word and the reserved words are left opaque and passed back
+// untouched.
+type dcb struct {
+	DCBlength  uint32
+	BaudRate   uint32
+	flags      uint32
+	wReserved  uint16
+	XonLim     uint16
+	XoffLim    uint16
+	ByteSize   byte
+	Parity     byte
+	StopBits   byte
+	XonChar    byte
+	XoffChar   byte
+	ErrorChar  byte
+	EofChar    byte
+	EvtChar    byte
+	wReserved1 uint16
+}
+
+// SetBaud reconfigures the line to baud without closing and reopening
+// the port, by rewriting BaudRate in the port's DCB and committing it
+// with SetCommState.
+func (p *Port) SetBaud(baud int) error {
+	if baud <= 0 {
+		return ErrBadBaud
+	}
+	var d dcb
+	d.DCBlength = uint32(unsafe.Sizeof(d))
+	if ret, _, err := procGetCommState.Call(uintptr(p.handle), uintptr(unsafe.Pointer(&d))); ret == 0 {
+		return err
+	}
+	d.BaudRate = uint32(baud)
+	if ret, _, err := procSetCommState.Call(uintptr(p.handle), uintptr(unsafe.Pointer(&d))); ret == 0 {
+		return err
+	}
+	return nil
+}
+
+// Baud returns the line's current baud rate as reported by the port's
+// DCB.
+func (p *Port) Baud() (int, error) {
+	var d dcb
+	d.DCBlength = uint32(unsafe.Sizeof(d))
+	if ret, _, err := procGetCommState.Call(uintptr(p.handle), uintptr(unsafe.Pointer(&d))); ret == 0 {
+		return 0, err
+	}
+	return int(d.BaudRate), nil
+}
+
+// WaitForModemChange is not implemented on this platform.
+func (p *Port) WaitForModemChange(ctx context.Context, mask uint) (uint, error) {
+	return 0, ErrNotSupported
+}
+
+// ModemEvents is not implemented on this platform; it returns a
+// channel that is immediately closed.
+func (p *Port) ModemEvents() <-chan ModemEvent {
+	ch := make(chan ModemEvent)
+	close(ch)
+	return ch
+}
+
+// SetRS485 is not implemented on this platform.
+func (p *Port) SetRS485(cfg RS485Config) error {
+	return ErrNotSupported
+}
+
+// SetReadDeadline is not implemented on this platform.
+func (p *Port) SetReadDeadline(t time.Time) error {
+	return ErrNotSupported
+}
+
+// SetWriteDeadline is not implemented on this platform.
+func (p *Port) SetWriteDeadline(t time.Time) error {
+	return ErrNotSupported
+}
+
+// SetDeadline is not implemented on this platform.
+func (p *Port) SetDeadline(t time.Time) error {
+	return ErrNotSupported
+}
+
+// resetReadDeadline is a no-op on this platform: deadlines aren't
+// implemented here, so ReadContext's SetReadDeadline(time.Now()) to
+// unblock a cancelled Read is itself already a no-op with nothing to
+// undo.
+func (p *Port) resetReadDeadline() error {
+	return nil
+}
+
+// resetWriteDeadline is a no-op on this platform, for the same reason
+// as resetReadDeadline.
+func (p *Port) resetWriteDeadline() error {
+	return nil
+}
@@ -0,0 +1,20 @@
+package serial
+
+import "time"
+
+// Transport is the behavior common to every backend this package can
+// hand back from OpenPort: the OS-specific local Port, the in-memory
+// Pipe, and the RFC 2217 network client. Protocol code (such as
+// package modbus) should depend on Transport rather than *Port so it
+// also works over a loopback Pipe in tests or a networked serial
+// server.
+type Transport interface {
+	Read(b []byte) (n int, err error)
+	Write(b []byte) (n int, err error)
+	Close() error
+	Flush() error
+	SendBreak(d time.Duration) error
+	SetDTR(v byte) error
+	SetRTS(v byte) error
+	GetStatus() (n uint, err error)
+}